@@ -1,36 +1,82 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
-	"regexp"
 	"strings"
 
 	"github.com/steebchen/prisma-client-go/binaries"
 	"github.com/steebchen/prisma-client-go/binaries/platform"
 	"github.com/steebchen/prisma-client-go/logger"
+	"github.com/steebchen/prisma-client-go/schema"
 )
 
+// RunOptions configures optional behavior for Run. It's variadic on Run so
+// existing callers that only pass arguments and output keep compiling.
+type RunOptions struct {
+	// DatasourceOverrides maps a datasource name (as declared in
+	// `datasource <name> { ... }` in schema.prisma) to a URL that overrides
+	// whatever is configured there, mirroring the query engine's
+	// OVERWRITE_DATASOURCES mechanism. This lets callers point a single
+	// checked-in schema at a different database per invocation.
+	DatasourceOverrides map[string]string
+
+	// Context, if set, cancels the underlying prisma process when done or
+	// canceled.
+	Context context.Context
+
+	// Stdout and Stderr, if set, additionally receive the child process's
+	// output, so callers can capture it (e.g. to inspect it for specific
+	// errors) independently of the output flag.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Env contains additional "KEY=VALUE" pairs appended to the child
+	// process environment.
+	Env []string
+}
+
 // Run the prisma CLI with given arguments
-func Run(arguments []string, output bool) error {
+func Run(arguments []string, output bool, opts ...RunOptions) error {
 	logger.Debug.Printf("running cli with args %+v", arguments)
-	// TODO respect initial PRISMA_<name>_BINARY env
-	// TODO optionally override CLI filepath using PRISMA_CLI_PATH
+
+	var options RunOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
 
 	dir := binaries.GlobalCacheDir()
 
-	if err := binaries.FetchNative(dir); err != nil {
-		return fmt.Errorf("could not fetch binaries: %w", err)
-	}
+	// An explicit PRISMA_CLI_PATH takes priority over the cached/fetched binary.
+	cliPathOverride := os.Getenv("PRISMA_CLI_PATH")
 
-	prisma := binaries.PrismaCLIName()
+	var prisma string
+	if cliPathOverride != "" {
+		logger.Debug.Printf("using PRISMA_CLI_PATH override: %s", cliPathOverride)
+		prisma = cliPathOverride
+	} else {
+		if allEngineBinariesOverridden(binaries.Engines) {
+			logger.Debug.Printf("all engine binaries overridden via PRISMA_<name>_BINARY, skipping fetch")
+		} else {
+			if overridden := overriddenEngineNames(binaries.Engines); len(overridden) > 0 {
+				logger.Debug.Printf("engines %s are overridden via PRISMA_<name>_BINARY, but binaries.FetchNative can't fetch a subset, so every engine is still being fetched", strings.Join(overridden, ", "))
+			}
+			if err := binaries.FetchNative(dir); err != nil {
+				return fmt.Errorf("could not fetch binaries: %w", err)
+			}
+		}
+
+		prisma = path.Join(dir, binaries.PrismaCLIName())
+	}
 
 	// Handle shim for schema compatibility
 	var cleanup func()
 	var err error
-	arguments, cleanup, err = shimSchemaCompatibility(arguments)
+	arguments, cleanup, err = shimSchemaCompatibility(arguments, options)
 	if err != nil {
 		return fmt.Errorf("failed to shim schema: %w", err)
 	}
@@ -38,9 +84,14 @@ func Run(arguments []string, output bool) error {
 		defer cleanup()
 	}
 
-	logger.Debug.Printf("running %s %+v", path.Join(dir, prisma), arguments)
+	logger.Debug.Printf("running %s %+v", prisma, arguments)
 
-	cmd := exec.Command(path.Join(dir, prisma), arguments...) //nolint:gosec
+	var cmd *exec.Cmd
+	if options.Context != nil {
+		cmd = exec.CommandContext(options.Context, prisma, arguments...) //nolint:gosec
+	} else {
+		cmd = exec.Command(prisma, arguments...) //nolint:gosec
+	}
 	binaryName := platform.CheckForExtension(platform.Name(), platform.BinaryPlatformNameStatic())
 
 	cmd.Env = os.Environ()
@@ -60,11 +111,26 @@ func Run(arguments []string, output bool) error {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", engine.Env, value))
 	}
 
+	cmd.Env = append(cmd.Env, options.Env...)
+
 	cmd.Stdin = os.Stdin
 
+	var stdoutWriters, stderrWriters []io.Writer
 	if output {
-		cmd.Stderr = os.Stderr
-		cmd.Stdout = os.Stdout
+		stdoutWriters = append(stdoutWriters, os.Stdout)
+		stderrWriters = append(stderrWriters, os.Stderr)
+	}
+	if options.Stdout != nil {
+		stdoutWriters = append(stdoutWriters, options.Stdout)
+	}
+	if options.Stderr != nil {
+		stderrWriters = append(stderrWriters, options.Stderr)
+	}
+	if len(stdoutWriters) > 0 {
+		cmd.Stdout = io.MultiWriter(stdoutWriters...)
+	}
+	if len(stderrWriters) > 0 {
+		cmd.Stderr = io.MultiWriter(stderrWriters...)
 	}
 
 	if err := cmd.Run(); err != nil {
@@ -74,112 +140,117 @@ func Run(arguments []string, output bool) error {
 	return nil
 }
 
-// shimSchemaCompatibility checks for a schema missing the 'url' property in the datasource block
-// and injects `url = env("DB_URL")` via a temporary file if needed.
-func shimSchemaCompatibility(args []string) ([]string, func(), error) {
-	schemaPath := findSchemaPath(args)
-	if schemaPath == "" {
-		// If no schema path is found, we can't do anything.
-		// It might be using default locations which we could check,
-		// but for now, let's rely on what we can find.
-		// Actually, if it is using default locations, we should probably check them too
-		// to be consistent.
-		// However, finding the schema path from args is the most reliable way if provided.
-		// If not provided, let's try to find it in default locations.
-		defaultPaths := []string{"./schema.prisma", "./prisma/schema.prisma"}
-		for _, p := range defaultPaths {
-			if _, err := os.Stat(p); err == nil {
-				schemaPath = p
-				break
-			}
+// allEngineBinariesOverridden reports whether every engine has a PRISMA_<name>_BINARY
+// env var pointing at a file that actually exists, meaning none of them need fetching.
+//
+// This only short-circuits the all-overridden case: binaries.FetchNative fetches
+// every engine in one call with no way to ask it for a subset, so a *partial*
+// override (some engines overridden, others not) still triggers a full fetch — see
+// overriddenEngineNames, which Run logs so that's visible rather than silent.
+// Skipping just the overridden engines needs a signature change in package
+// binaries; tracked as a follow-up, not done here.
+func allEngineBinariesOverridden(engines []binaries.Engine) bool {
+	for _, engine := range engines {
+		if !engineBinaryOverridden(engine) {
+			return false
 		}
 	}
+	return true
+}
 
-	if schemaPath == "" {
-		return args, nil, nil
+// overriddenEngineNames returns the names of engines with a PRISMA_<name>_BINARY
+// override already in place, so a caller logging a full fetch can say exactly
+// which engines didn't need it.
+func overriddenEngineNames(engines []binaries.Engine) []string {
+	var names []string
+	for _, engine := range engines {
+		if engineBinaryOverridden(engine) {
+			names = append(names, engine.Name)
+		}
 	}
+	return names
+}
 
-	content, err := os.ReadFile(schemaPath)
-	if err != nil {
-		// If we can't read the file, just proceed as is.
-		return args, nil, nil
+func engineBinaryOverridden(engine binaries.Engine) bool {
+	env := os.Getenv(engine.Env)
+	if env == "" {
+		return false
 	}
+	info, err := os.Stat(env)
+	return err == nil && !info.IsDir()
+}
 
-	schemaStr := string(content)
+// SchemaSearchPaths are extra candidate schema locations checked, in order,
+// after defaultSchemaPaths, when args has no explicit --schema. Can also be
+// set via PRISMA_SCHEMA_SEARCH_PATHS (colon-separated).
+var SchemaSearchPaths []string
 
-	// Simple regex to find the datasource block
-	// datasource db {
-	//   provider = "..."
-	// }
-	datasourceRegex := regexp.MustCompile(`(?s)datasource\s+\w+\s+\{([^}]+)\}`)
-	match := datasourceRegex.FindStringSubmatchIndex(schemaStr)
+// defaultSchemaPaths are the locations Prisma itself looks for a schema in.
+var defaultSchemaPaths = []string{"./schema.prisma", "./prisma/schema.prisma"}
 
-	if len(match) < 4 {
-		return args, nil, nil
+// shimSchemaCompatibility checks for a schema missing the 'url' property in the datasource block,
+// injecting `url = env("DB_URL")` via a temporary file if needed, and applies any datasource URL
+// overrides requested via options.
+func shimSchemaCompatibility(args []string, options RunOptions) ([]string, func(), error) {
+	schemaPath := findSchemaPath(args)
+	if schemaPath == "" {
+		resolved, err := resolveSchemaSearchPath()
+		if err != nil {
+			// Not every command needs a schema (e.g. `prisma version`), so
+			// this is only logged, not propagated; the CLI itself will
+			// surface a clear error if it actually needed one.
+			logger.Debug.Printf("%s", err)
+		} else {
+			schemaPath = resolved
+		}
 	}
 
-	// match[2] and match[3] capture the content inside the brace
-	blockStart, blockEnd := match[2], match[3]
-	blockContent := schemaStr[blockStart:blockEnd]
-
-	// Check if 'url' is present in the block
-	// We look for 'url\s*='
-	urlRegex := regexp.MustCompile(`\burl\s*=`)
-	if urlRegex.MatchString(blockContent) {
-		// url exists, no need to shim
+	if schemaPath == "" {
 		return args, nil, nil
 	}
 
-	// Inject url = env("DB_URL")
-	logger.Info.Printf("Injected url = env(\"DB_URL\") into datasource block for compatibility.")
-
-	// We insert it at the beginning of the block content
-	newSchemaStr := schemaStr[:blockStart] + "\n  url = env(\"DB_URL\")" + schemaStr[blockStart:]
+	patches := []schema.PatchFunc{schema.EnsureDatasourceURL("DB_URL")}
+	for name, url := range options.DatasourceOverrides {
+		logger.Debug.Printf("overriding datasource %s url", name)
+		patches = append(patches, schema.OverrideDatasourceURL(name, url))
+	}
 
-	// Create temp file
-	tmpFile, err := os.CreateTemp("", "schema-*.prisma")
+	patchedPath, cleanup, err := schema.Patch(schemaPath, patches...)
 	if err != nil {
-		return args, nil, fmt.Errorf("could not create temp schema file: %w", err)
+		return args, nil, fmt.Errorf("could not patch schema: %w", err)
 	}
 
-	if _, err := tmpFile.WriteString(newSchemaStr); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return args, nil, fmt.Errorf("could not write to temp schema file: %w", err)
+	// cleanup is only set when schema.Patch actually wrote a temp file, i.e.
+	// a patch changed something. Otherwise patchedPath is schemaPath as
+	// passed in (directory or file) and there's nothing to rewrite in args.
+	if cleanup == nil {
+		return args, nil, nil
 	}
-	tmpFile.Close()
 
-	// Update args to point to the new schema
-	newArgs := make([]string, len(args))
-	copy(newArgs, args)
+	logger.Info.Printf("patched schema for compatibility/overrides at %s", patchedPath)
 
-	found := false
-	for i, arg := range newArgs {
-		if arg == "--schema" && i+1 < len(newArgs) {
-			newArgs[i+1] = tmpFile.Name()
-			found = true
-			break
-		}
-		if strings.HasPrefix(arg, "--schema=") {
-			newArgs[i] = "--schema=" + tmpFile.Name()
-			found = true
-			break
-		}
-	}
+	return setSchemaArg(args, patchedPath), cleanup, nil
+}
 
-	if !found {
-		// If schema arg wasn't present, we need to append it.
-		// But wait, the CLI commands usually take flags.
-		// If we are injecting a schema file, we should make sure the command accepts it.
-		// Most commands like validate, migrate, db push accept --schema.
-		newArgs = append(newArgs, "--schema", tmpFile.Name())
+// resolveSchemaSearchPath walks defaultSchemaPaths, SchemaSearchPaths, and
+// PRISMA_SCHEMA_SEARCH_PATHS (colon-separated) in order, returning the
+// first path that exists. If none exist, it returns an error listing every
+// path it tried.
+func resolveSchemaSearchPath() (string, error) {
+	candidates := append([]string{}, defaultSchemaPaths...)
+	candidates = append(candidates, SchemaSearchPaths...)
+	if env := os.Getenv("PRISMA_SCHEMA_SEARCH_PATHS"); env != "" {
+		candidates = append(candidates, strings.Split(env, ":")...)
 	}
 
-	cleanup := func() {
-		os.Remove(tmpFile.Name())
+	for _, p := range candidates {
+		logger.Debug.Printf("looking for schema at %s", p)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
 	}
 
-	return newArgs, cleanup, nil
+	return "", fmt.Errorf("could not find a Prisma schema, tried: %s", strings.Join(candidates, ", "))
 }
 
 func findSchemaPath(args []string) string {
@@ -193,3 +264,24 @@ func findSchemaPath(args []string) string {
 	}
 	return ""
 }
+
+// setSchemaArg returns a copy of args with --schema (in either "--schema x"
+// or "--schema=x" form) pointed at schemaPath, appending "--schema
+// schemaPath" if it wasn't present before.
+func setSchemaArg(args []string, schemaPath string) []string {
+	newArgs := make([]string, len(args))
+	copy(newArgs, args)
+
+	for i, arg := range newArgs {
+		if arg == "--schema" && i+1 < len(newArgs) {
+			newArgs[i+1] = schemaPath
+			return newArgs
+		}
+		if strings.HasPrefix(arg, "--schema=") {
+			newArgs[i] = "--schema=" + schemaPath
+			return newArgs
+		}
+	}
+
+	return append(newArgs, "--schema", schemaPath)
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steebchen/prisma-client-go/binaries"
+)
+
+func TestAllEngineBinariesOverridden(t *testing.T) {
+	dir := t.TempDir()
+	overrideFile := filepath.Join(dir, "query-engine")
+	if err := os.WriteFile(overrideFile, []byte("fake binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	queryEngine := binaries.Engine{Name: "query-engine", Env: "PRISMA_QUERY_ENGINE_BINARY_TEST"}
+	migrationEngine := binaries.Engine{Name: "migration-engine", Env: "PRISMA_MIGRATION_ENGINE_BINARY_TEST"}
+
+	t.Run("false when no engine is overridden", func(t *testing.T) {
+		if allEngineBinariesOverridden([]binaries.Engine{queryEngine, migrationEngine}) {
+			t.Fatal("expected false")
+		}
+	})
+
+	t.Run("false when only some engines are overridden", func(t *testing.T) {
+		t.Setenv(queryEngine.Env, overrideFile)
+		t.Setenv(migrationEngine.Env, "")
+
+		if allEngineBinariesOverridden([]binaries.Engine{queryEngine, migrationEngine}) {
+			t.Fatal("expected false for a partial override")
+		}
+
+		got := overriddenEngineNames([]binaries.Engine{queryEngine, migrationEngine})
+		if len(got) != 1 || got[0] != queryEngine.Name {
+			t.Fatalf("expected only %q to be reported overridden, got %v", queryEngine.Name, got)
+		}
+	})
+
+	t.Run("true when every engine is overridden", func(t *testing.T) {
+		t.Setenv(queryEngine.Env, overrideFile)
+		t.Setenv(migrationEngine.Env, overrideFile)
+
+		if !allEngineBinariesOverridden([]binaries.Engine{queryEngine, migrationEngine}) {
+			t.Fatal("expected true")
+		}
+	})
+
+	t.Run("false when the override path doesn't exist", func(t *testing.T) {
+		t.Setenv(queryEngine.Env, filepath.Join(dir, "does-not-exist"))
+
+		if allEngineBinariesOverridden([]binaries.Engine{queryEngine}) {
+			t.Fatal("expected false for a dangling override path")
+		}
+	})
+}
+
+func TestFindSchemaPath(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"space form", []string{"generate", "--schema", "a.prisma"}, "a.prisma"},
+		{"equals form", []string{"generate", "--schema=b.prisma"}, "b.prisma"},
+		{"not present", []string{"generate"}, ""},
+		{"dangling flag is ignored", []string{"generate", "--schema"}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := findSchemaPath(c.args); got != c.want {
+				t.Fatalf("findSchemaPath(%v) = %q, want %q", c.args, got, c.want)
+			}
+		})
+	}
+}
+
+func TestSetSchemaArg(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"rewrites space form", []string{"generate", "--schema", "a.prisma"}, []string{"generate", "--schema", "new.prisma"}},
+		{"rewrites equals form", []string{"generate", "--schema=a.prisma"}, []string{"generate", "--schema=new.prisma"}},
+		{"appends when absent", []string{"generate"}, []string{"generate", "--schema", "new.prisma"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := setSchemaArg(c.args, "new.prisma")
+			if strings.Join(got, "\x00") != strings.Join(c.want, "\x00") {
+				t.Fatalf("setSchemaArg(%v) = %v, want %v", c.args, got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolveSchemaSearchPath(t *testing.T) {
+	dir := t.TempDir()
+
+	origWD, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(origWD) })
+
+	origSearchPaths := SchemaSearchPaths
+	t.Cleanup(func() { SchemaSearchPaths = origSearchPaths })
+
+	t.Run("errors listing every candidate when nothing exists", func(t *testing.T) {
+		SchemaSearchPaths = nil
+		t.Setenv("PRISMA_SCHEMA_SEARCH_PATHS", "")
+
+		_, err := resolveSchemaSearchPath()
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		for _, want := range defaultSchemaPaths {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("error %q doesn't mention tried path %q", err, want)
+			}
+		}
+	})
+
+	t.Run("falls back to SchemaSearchPaths", func(t *testing.T) {
+		schemaFile := filepath.Join(dir, "nested", "schema.prisma")
+		if err := os.MkdirAll(filepath.Dir(schemaFile), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(schemaFile, []byte("datasource db {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		SchemaSearchPaths = []string{schemaFile}
+		t.Setenv("PRISMA_SCHEMA_SEARCH_PATHS", "")
+
+		got, err := resolveSchemaSearchPath()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != schemaFile {
+			t.Fatalf("got %q, want %q", got, schemaFile)
+		}
+	})
+
+	t.Run("falls back to PRISMA_SCHEMA_SEARCH_PATHS", func(t *testing.T) {
+		schemaFile := filepath.Join(dir, "env-nested", "schema.prisma")
+		if err := os.MkdirAll(filepath.Dir(schemaFile), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(schemaFile, []byte("datasource db {}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		SchemaSearchPaths = nil
+		t.Setenv("PRISMA_SCHEMA_SEARCH_PATHS", "/does/not/exist:"+schemaFile)
+
+		got, err := resolveSchemaSearchPath()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != schemaFile {
+			t.Fatalf("got %q, want %q", got, schemaFile)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrDriftDetected is returned by DBPush, MigrateDev, and MigrateDeploy when
+// Prisma detects that the database has drifted from the migration history
+// and refuses to proceed without an explicit reset.
+var ErrDriftDetected = errors.New("prisma: schema drift detected")
+
+// ErrMigrationFailed is returned by DBPush, MigrateDev, and MigrateDeploy
+// when the command fails for a reason other than drift.
+var ErrMigrationFailed = errors.New("prisma: migration failed")
+
+// CaptureOpts is embedded by facade Opts types that run a migration command
+// and classify its failure. Quiet suppresses the default passthrough to the
+// process's own stdout/stderr (the output flag Run normally gets); Stdout
+// and Stderr, if set, additionally receive the raw CLI output regardless of
+// Quiet.
+type CaptureOpts struct {
+	Quiet  bool
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// GenerateOpts configures Generate.
+type GenerateOpts struct {
+	Schema string
+	Watch  bool
+	// Postinstall marks the invocation as running from a package manager's
+	// postinstall hook, matching the official Prisma CLI's
+	// PRISMA_GENERATE_IN_POSTINSTALL env var.
+	Postinstall bool
+}
+
+// Generate runs `prisma generate` for the given schema.
+func Generate(ctx context.Context, opts GenerateOpts) error {
+	args := appendSchemaArg([]string{"generate"}, opts.Schema)
+	if opts.Watch {
+		args = append(args, "--watch")
+	}
+
+	options := RunOptions{Context: ctx}
+	if opts.Postinstall {
+		options.Env = []string{"PRISMA_GENERATE_IN_POSTINSTALL=true"}
+	}
+
+	return Run(args, true, options)
+}
+
+// DBPushOpts configures DBPush.
+type DBPushOpts struct {
+	CaptureOpts
+
+	Schema         string
+	AcceptDataLoss bool
+	ForceReset     bool
+	SkipGenerate   bool
+}
+
+// DBPush runs `prisma db push` for the given schema, returning
+// ErrDriftDetected if the CLI's output reports drift, or ErrMigrationFailed
+// for any other failure.
+func DBPush(ctx context.Context, opts DBPushOpts) error {
+	args := appendSchemaArg([]string{"db", "push"}, opts.Schema)
+	if opts.AcceptDataLoss {
+		args = append(args, "--accept-data-loss")
+	}
+	if opts.ForceReset {
+		args = append(args, "--force-reset")
+	}
+	if opts.SkipGenerate {
+		args = append(args, "--skip-generate")
+	}
+
+	return runDetectingDrift(ctx, args, opts.CaptureOpts)
+}
+
+// MigrateDevOpts configures MigrateDev.
+type MigrateDevOpts struct {
+	CaptureOpts
+
+	Schema     string
+	Name       string
+	CreateOnly bool
+	SkipSeed   bool
+}
+
+// MigrateDev runs `prisma migrate dev` for the given schema, returning
+// ErrDriftDetected or ErrMigrationFailed if Prisma can't apply it cleanly.
+func MigrateDev(ctx context.Context, opts MigrateDevOpts) error {
+	args := appendSchemaArg([]string{"migrate", "dev"}, opts.Schema)
+	if opts.Name != "" {
+		args = append(args, "--name", opts.Name)
+	}
+	if opts.CreateOnly {
+		args = append(args, "--create-only")
+	}
+	if opts.SkipSeed {
+		args = append(args, "--skip-seed")
+	}
+
+	return runDetectingDrift(ctx, args, opts.CaptureOpts)
+}
+
+// MigrateDeployOpts configures MigrateDeploy.
+type MigrateDeployOpts struct {
+	CaptureOpts
+
+	Schema string
+}
+
+// MigrateDeploy runs `prisma migrate deploy` for the given schema,
+// returning ErrMigrationFailed if a migration fails to apply.
+func MigrateDeploy(ctx context.Context, opts MigrateDeployOpts) error {
+	args := appendSchemaArg([]string{"migrate", "deploy"}, opts.Schema)
+	return runDetectingDrift(ctx, args, opts.CaptureOpts)
+}
+
+// MigrateStatusOpts configures MigrateStatus.
+type MigrateStatusOpts struct {
+	Schema string
+}
+
+// MigrateStatus runs `prisma migrate status` for the given schema.
+func MigrateStatus(ctx context.Context, opts MigrateStatusOpts) error {
+	args := appendSchemaArg([]string{"migrate", "status"}, opts.Schema)
+	return Run(args, true, RunOptions{Context: ctx})
+}
+
+// ValidateOpts configures Validate.
+type ValidateOpts struct {
+	Schema string
+}
+
+// Validate runs `prisma validate` for the given schema.
+func Validate(ctx context.Context, opts ValidateOpts) error {
+	args := appendSchemaArg([]string{"validate"}, opts.Schema)
+	return Run(args, true, RunOptions{Context: ctx})
+}
+
+// FormatOpts configures Format.
+type FormatOpts struct {
+	Schema string
+}
+
+// Format runs `prisma format` for the given schema.
+func Format(ctx context.Context, opts FormatOpts) error {
+	args := appendSchemaArg([]string{"format"}, opts.Schema)
+	return Run(args, true, RunOptions{Context: ctx})
+}
+
+// appendSchemaArg appends "--schema schemaPath" to args, or returns args
+// unchanged if schemaPath is empty, letting the CLI fall back to its own
+// schema discovery.
+func appendSchemaArg(args []string, schemaPath string) []string {
+	if schemaPath == "" {
+		return args
+	}
+	return append(args, "--schema", schemaPath)
+}
+
+// runDetectingDrift runs args, capturing stdout and stderr separately (never
+// sharing one io.Writer between them, since Run may copy into them from
+// concurrent goroutines) so the output can be classified into
+// ErrDriftDetected or ErrMigrationFailed instead of a bare exec error.
+func runDetectingDrift(ctx context.Context, args []string, capture CaptureOpts) error {
+	var stdoutBuf, stderrBuf bytes.Buffer
+
+	err := Run(args, !capture.Quiet, RunOptions{
+		Context: ctx,
+		Stdout:  teeIfSet(&stdoutBuf, capture.Stdout),
+		Stderr:  teeIfSet(&stderrBuf, capture.Stderr),
+	})
+
+	return classifyMigrateError(stdoutBuf.String()+stderrBuf.String(), err)
+}
+
+// teeIfSet returns primary alone, or a writer that also tees into extra when
+// extra is non-nil.
+func teeIfSet(primary *bytes.Buffer, extra io.Writer) io.Writer {
+	if extra == nil {
+		return primary
+	}
+	return io.MultiWriter(primary, extra)
+}
+
+// classifyMigrateError turns a raw exec error into ErrDriftDetected or
+// ErrMigrationFailed based on the CLI's captured output. This is a
+// best-effort heuristic against Prisma's human-readable text, not a stable
+// contract — Prisma doesn't expose a structured exit code or error type for
+// drift detection today, so wording changes upstream could require this to
+// be revisited.
+func classifyMigrateError(output string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if strings.Contains(strings.ToLower(output), "drift") {
+		return fmt.Errorf("%w: %s", ErrDriftDetected, strings.TrimSpace(output))
+	}
+
+	return fmt.Errorf("%w: %s", ErrMigrationFailed, err)
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyMigrateError(t *testing.T) {
+	execErr := errors.New("exit status 1")
+
+	t.Run("nil error stays nil", func(t *testing.T) {
+		if err := classifyMigrateError("anything", nil); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	})
+
+	t.Run("drift wording maps to ErrDriftDetected", func(t *testing.T) {
+		err := classifyMigrateError("Drift detected: Your database schema is not in sync", execErr)
+		if !errors.Is(err, ErrDriftDetected) {
+			t.Fatalf("expected ErrDriftDetected, got %v", err)
+		}
+	})
+
+	t.Run("drift detection is case-insensitive", func(t *testing.T) {
+		err := classifyMigrateError("a DRIFT was found", execErr)
+		if !errors.Is(err, ErrDriftDetected) {
+			t.Fatalf("expected ErrDriftDetected, got %v", err)
+		}
+	})
+
+	t.Run("anything else maps to ErrMigrationFailed", func(t *testing.T) {
+		err := classifyMigrateError("some unrelated migration error", execErr)
+		if !errors.Is(err, ErrMigrationFailed) {
+			t.Fatalf("expected ErrMigrationFailed, got %v", err)
+		}
+		if errors.Is(err, ErrDriftDetected) {
+			t.Fatal("did not expect ErrDriftDetected")
+		}
+	})
+}
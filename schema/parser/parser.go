@@ -0,0 +1,186 @@
+// Package parser implements a small, dependency-free tokenizer for locating
+// top-level blocks in a Prisma schema file (e.g. `datasource db { ... }`).
+//
+// It understands just enough of the Prisma schema grammar — comments and
+// string literals — to track brace depth correctly, so a block's
+// boundaries can be found reliably even when braces are nested inside the
+// block or appear inside a comment or string literal.
+package parser
+
+import "strings"
+
+// Block describes a located `keyword name { ... }` block. Start and End
+// delimit the block body, i.e. the content between (but not including) the
+// braces.
+type Block struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// FindBlock returns the first top-level block introduced by the given
+// keyword (e.g. "datasource" or "generator") in src.
+func FindBlock(src, keyword string) (Block, bool) {
+	blocks := FindBlocks(src, keyword)
+	if len(blocks) == 0 {
+		return Block{}, false
+	}
+	return blocks[0], true
+}
+
+// FindNamedBlock returns the top-level block introduced by the given
+// keyword with the given name, e.g. FindNamedBlock(src, "datasource", "db").
+func FindNamedBlock(src, keyword, name string) (Block, bool) {
+	for _, block := range FindBlocks(src, keyword) {
+		if block.Name == name {
+			return block, true
+		}
+	}
+	return Block{}, false
+}
+
+// FindBlocks returns every top-level block introduced by the given keyword,
+// in source order.
+func FindBlocks(src, keyword string) []Block {
+	tokens := tokenize(src)
+
+	var blocks []Block
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.kind != tokWord || tok.text != keyword {
+			continue
+		}
+
+		// expect: keyword <name> {
+		j := i + 1
+		if j >= len(tokens) || tokens[j].kind != tokWord {
+			continue
+		}
+		name := tokens[j].text
+
+		j++
+		if j >= len(tokens) || tokens[j].kind != tokBrace || tokens[j].text != "{" {
+			continue
+		}
+		bodyStart := tokens[j].end
+
+		depth := 1
+		for j++; j < len(tokens); j++ {
+			if tokens[j].kind != tokBrace {
+				continue
+			}
+			if tokens[j].text == "{" {
+				depth++
+				continue
+			}
+			depth--
+			if depth == 0 {
+				blocks = append(blocks, Block{Name: name, Start: bodyStart, End: tokens[j].start})
+				break
+			}
+		}
+	}
+
+	return blocks
+}
+
+// FindField locates the first `name = ...` assignment in src, skipping any
+// occurrence inside a comment or string literal (e.g. a commented-out
+// `// name = ...` line left above the real one). It returns the byte range
+// from the start of name to the end of that line, suitable for replacing
+// the whole assignment, or ok=false if name isn't assigned anywhere in src.
+func FindField(src, name string) (start, end int, ok bool) {
+	tokens := tokenize(src)
+
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].kind != tokWord || tokens[i].text != name {
+			continue
+		}
+		if i+1 >= len(tokens) || tokens[i+1].kind != tokEquals {
+			continue
+		}
+
+		start = tokens[i].start
+		if nl := strings.IndexByte(src[start:], '\n'); nl >= 0 {
+			end = start + nl
+		} else {
+			end = len(src)
+		}
+		return start, end, true
+	}
+
+	return 0, 0, false
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokBrace
+	tokEquals
+)
+
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int
+}
+
+// tokenize walks src once, skipping comments and string literals, and emits
+// words, braces, and '=' along with their byte offsets. Everything else is
+// discarded since FindBlocks and FindField only need to track identifiers,
+// braces, and assignment signs.
+func tokenize(src string) []token {
+	var tokens []token
+	i, n := 0, len(src)
+
+	for i < n {
+		c := src[i]
+
+		switch {
+		case c == '/' && i+1 < n && src[i+1] == '/':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			if end := strings.Index(src[i+2:], "*/"); end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+		case c == '"':
+			i++
+			for i < n && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case c == '{' || c == '}':
+			tokens = append(tokens, token{kind: tokBrace, text: string(c), start: i, end: i + 1})
+			i++
+		case c == '=':
+			tokens = append(tokens, token{kind: tokEquals, text: "=", start: i, end: i + 1})
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < n && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: src[start:i], start: start, end: i})
+		default:
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
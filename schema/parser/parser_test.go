@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindBlock(t *testing.T) {
+	src := `
+datasource db {
+  provider = "postgresql"
+  url      = env("DATABASE_URL")
+}
+
+model User {
+  id    Int    @id
+  posts Post[]
+}
+`
+
+	block, ok := FindBlock(src, "datasource")
+	if !ok {
+		t.Fatal("expected to find a datasource block")
+	}
+	if block.Name != "db" {
+		t.Fatalf("expected block name %q, got %q", "db", block.Name)
+	}
+
+	content := src[block.Start:block.End]
+	if !strings.Contains(content, `provider = "postgresql"`) {
+		t.Fatalf("block content missing provider field: %q", content)
+	}
+	if strings.Contains(content, "model User") {
+		t.Fatalf("block content leaked past its closing brace: %q", content)
+	}
+}
+
+func TestFindBlockStopsBeforeLaterModelAttributes(t *testing.T) {
+	src := `
+datasource db {
+  url = env("DATABASE_URL")
+}
+
+model Post {
+  id   Int @id
+  tags String[]
+
+  @@index([id, tags])
+}
+`
+
+	block, ok := FindBlock(src, "datasource")
+	if !ok {
+		t.Fatal("expected to find a datasource block")
+	}
+
+	content := src[block.Start:block.End]
+	if strings.Contains(content, "@@index") {
+		t.Fatalf("a later model's @@index attribute ended up inside the datasource block: %q", content)
+	}
+}
+
+func TestFindBlockIgnoresCommentsWithBraces(t *testing.T) {
+	src := `
+// a datasource block looks like: datasource db { url = "..." }
+datasource db {
+  // relationMode = "prisma" needs braces too: {}
+  url = env("DATABASE_URL")
+  /* a block comment with a brace } right in it */
+  provider = "postgresql"
+}
+`
+
+	block, ok := FindBlock(src, "datasource")
+	if !ok {
+		t.Fatal("expected to find a datasource block")
+	}
+
+	content := src[block.Start:block.End]
+	if !strings.Contains(content, `provider = "postgresql"`) {
+		t.Fatalf("comments with braces threw off brace-depth tracking: %q", content)
+	}
+}
+
+func TestFindBlockIgnoresBracesInsideStrings(t *testing.T) {
+	src := `
+datasource db {
+  url = env("DATABASE_URL")
+  comment = "not a real block: { }"
+}
+`
+
+	block, ok := FindBlock(src, "datasource")
+	if !ok {
+		t.Fatal("expected to find a datasource block")
+	}
+
+	content := src[block.Start:block.End]
+	if !strings.Contains(content, `comment = "not a real block: { }"`) {
+		t.Fatalf("braces inside a string literal threw off brace-depth tracking: %q", content)
+	}
+}
+
+func TestFindBlockNotFound(t *testing.T) {
+	if _, ok := FindBlock(`model User { id Int @id }`, "datasource"); ok {
+		t.Fatal("expected no datasource block to be found")
+	}
+}
+
+func TestFindNamedBlock(t *testing.T) {
+	src := `
+datasource db {
+  url = env("DATABASE_URL")
+}
+
+datasource other {
+  url = env("OTHER_URL")
+}
+`
+
+	block, ok := FindNamedBlock(src, "datasource", "other")
+	if !ok {
+		t.Fatal("expected to find the 'other' datasource block")
+	}
+
+	content := src[block.Start:block.End]
+	if !strings.Contains(content, "OTHER_URL") {
+		t.Fatalf("found the wrong block: %q", content)
+	}
+}
+
+func TestFindField(t *testing.T) {
+	t.Run("finds a plain assignment", func(t *testing.T) {
+		src := "  provider = \"postgresql\"\n  url      = env(\"DATABASE_URL\")\n"
+
+		start, end, ok := FindField(src, "url")
+		if !ok {
+			t.Fatal("expected to find url")
+		}
+		if got := src[start:end]; !strings.Contains(got, `env("DATABASE_URL")`) {
+			t.Fatalf("expected the matched range to cover the value, got %q", got)
+		}
+	})
+
+	t.Run("skips a commented-out occurrence", func(t *testing.T) {
+		src := "  // url = env(\"OLD_URL\")\n  url = env(\"DATABASE_URL\")\n"
+
+		start, end, ok := FindField(src, "url")
+		if !ok {
+			t.Fatal("expected to find url")
+		}
+		if got := src[start:end]; strings.Contains(got, "OLD_URL") {
+			t.Fatalf("matched the commented-out line instead of the real assignment: %q", got)
+		} else if !strings.Contains(got, "DATABASE_URL") {
+			t.Fatalf("expected the matched range to cover the real assignment, got %q", got)
+		}
+	})
+
+	t.Run("skips an occurrence inside a string literal", func(t *testing.T) {
+		src := `  comment = "not a real field: url = \"nope\""` + "\n  url = env(\"DATABASE_URL\")\n"
+
+		start, end, ok := FindField(src, "url")
+		if !ok {
+			t.Fatal("expected to find url")
+		}
+		if got := src[start:end]; strings.Contains(got, "nope") {
+			t.Fatalf("matched the string literal instead of the real assignment: %q", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, _, ok := FindField("provider = \"postgresql\"\n", "url"); ok {
+			t.Fatal("expected url not to be found")
+		}
+	})
+}
@@ -0,0 +1,217 @@
+// Package schema provides safe, minimal patches for a user's schema.prisma
+// that cli.Run applies before invoking the Prisma CLI, instead of requiring
+// the user to edit their checked-in schema.
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steebchen/prisma-client-go/schema/parser"
+)
+
+// PatchFunc mutates a schema's source, returning the new source and whether
+// it actually changed anything.
+type PatchFunc func(src string) (string, bool)
+
+// Patch resolves path to a concrete schema file (see ResolvePath, which
+// understands multi-file schema folders) and applies patches to it in
+// order. If none of the patches change anything, path is returned
+// unchanged — notably, if path was itself a directory (a multi-file
+// schema), it stays a directory, so the caller keeps passing the whole
+// folder to the CLI rather than silently narrowing it down to the single
+// file ResolvePath happened to use for reading. If a patch does change the
+// content, the result is written to a temp location and that path is
+// returned alongside a cleanup func that removes it (callers should defer
+// cleanup when non-nil): a temp file when path was a single file, or a temp
+// directory containing the patched file plus untouched copies of every
+// other *.prisma sibling when path was a directory, so the whole multi-file
+// schema is still present for the CLI to read.
+func Patch(path string, patches ...PatchFunc) (resultPath string, cleanup func(), err error) {
+	info, statErr := os.Stat(path)
+	isDir := statErr == nil && info.IsDir()
+
+	resolved, err := ResolvePath(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		// Nothing we can patch; let the CLI surface its own error for a
+		// missing or unreadable schema.
+		return path, nil, nil
+	}
+
+	src := string(content)
+	var changed bool
+	for _, patch := range patches {
+		var did bool
+		if src, did = patch(src); did {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return path, nil, nil
+	}
+
+	if !isDir {
+		tmpFile, err := os.CreateTemp("", "schema-*.prisma")
+		if err != nil {
+			return "", nil, fmt.Errorf("could not create temp schema file: %w", err)
+		}
+
+		if _, err := tmpFile.WriteString(src); err != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+			return "", nil, fmt.Errorf("could not write to temp schema file: %w", err)
+		}
+		tmpFile.Close()
+
+		return tmpFile.Name(), func() { os.Remove(tmpFile.Name()) }, nil
+	}
+
+	return patchDir(path, filepath.Base(resolved), src)
+}
+
+// patchDir writes patchedContent (the already-patched contents of the file
+// named patchedName within dir) into a fresh temp directory, alongside
+// unmodified copies of dir's other *.prisma files, so a multi-file schema
+// keeps every sibling file once one of them is patched. It returns the temp
+// directory's path and a cleanup func that removes it.
+func patchDir(dir, patchedName, patchedContent string) (resultPath string, cleanup func(), err error) {
+	tmpDir, err := os.MkdirTemp("", "schema-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create temp schema directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("could not read schema directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".prisma" {
+			continue
+		}
+
+		dest := filepath.Join(tmpDir, entry.Name())
+
+		if entry.Name() == patchedName {
+			if err := os.WriteFile(dest, []byte(patchedContent), 0o644); err != nil {
+				cleanup()
+				return "", nil, fmt.Errorf("could not write patched schema file: %w", err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("could not read schema file %s: %w", entry.Name(), err)
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("could not copy schema file %s: %w", entry.Name(), err)
+		}
+	}
+
+	return tmpDir, cleanup, nil
+}
+
+// ResolvePath turns a --schema argument into a concrete schema file. Recent
+// Prisma versions allow --schema to point at a directory containing
+// multiple *.prisma files (a "multi-file schema"); in that case we look for
+// the conventional schema.prisma first, then fall back to the first
+// *.prisma file that declares a datasource block.
+func ResolvePath(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return path, nil
+	}
+
+	if def := filepath.Join(path, "schema.prisma"); fileExists(def) {
+		return def, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read schema directory %s: %w", path, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".prisma" {
+			continue
+		}
+
+		file := filepath.Join(path, entry.Name())
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := parser.FindBlock(string(content), "datasource"); ok {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("no schema.prisma or *.prisma file with a datasource block found in %s", path)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func hasField(blockContent, name string) bool {
+	_, _, ok := parser.FindField(blockContent, name)
+	return ok
+}
+
+// EnsureDatasourceURL returns a PatchFunc that injects `url = env(envVar)`
+// into the first datasource block, but only if that block doesn't already
+// declare a url, mirroring what older prisma-client-go versions required
+// users to add to schema.prisma by hand.
+func EnsureDatasourceURL(envVar string) PatchFunc {
+	return func(src string) (string, bool) {
+		block, ok := parser.FindBlock(src, "datasource")
+		if !ok {
+			return src, false
+		}
+
+		if hasField(src[block.Start:block.End], "url") {
+			return src, false
+		}
+
+		injected := fmt.Sprintf("\n  url = env(%q)", envVar)
+		return src[:block.Start] + injected + src[block.Start:], true
+	}
+}
+
+// OverrideDatasourceURL returns a PatchFunc that rewrites (or injects, if
+// missing) the url field of the named datasource block to url, mirroring
+// the query engine's OVERWRITE_DATASOURCES mechanism. It's a no-op if the
+// named datasource doesn't exist in the schema.
+func OverrideDatasourceURL(name, url string) PatchFunc {
+	return func(src string) (string, bool) {
+		block, ok := parser.FindNamedBlock(src, "datasource", name)
+		if !ok {
+			return src, false
+		}
+
+		content := src[block.Start:block.End]
+		replacement := fmt.Sprintf("url = %q", url)
+
+		if start, end, ok := parser.FindField(content, "url"); ok {
+			newContent := content[:start] + replacement + content[end:]
+			return src[:block.Start] + newContent + src[block.End:], true
+		}
+
+		injected := fmt.Sprintf("\n  %s", replacement)
+		return src[:block.Start] + injected + src[block.Start:], true
+	}
+}
@@ -0,0 +1,242 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+}
+
+func TestEnsureDatasourceURL(t *testing.T) {
+	t.Run("injects when missing", func(t *testing.T) {
+		src := "datasource db {\n  provider = \"postgresql\"\n}\n"
+		out, changed := EnsureDatasourceURL("DB_URL")(src)
+		if !changed {
+			t.Fatal("expected a change")
+		}
+		if !strings.Contains(out, `url = env("DB_URL")`) {
+			t.Fatalf("url was not injected: %q", out)
+		}
+	})
+
+	t.Run("no-op when already present", func(t *testing.T) {
+		src := "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n"
+		out, changed := EnsureDatasourceURL("DB_URL")(src)
+		if changed {
+			t.Fatalf("expected no change, got %q", out)
+		}
+		if out != src {
+			t.Fatalf("source was mutated despite changed=false: %q", out)
+		}
+	})
+
+	t.Run("no-op when no datasource block", func(t *testing.T) {
+		src := "model User {\n  id Int @id\n}\n"
+		out, changed := EnsureDatasourceURL("DB_URL")(src)
+		if changed || out != src {
+			t.Fatalf("expected no change for a schema without a datasource block, got %q", out)
+		}
+	})
+}
+
+func TestOverrideDatasourceURL(t *testing.T) {
+	src := "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n"
+
+	out, changed := OverrideDatasourceURL("db", "postgres://override")(src)
+	if !changed {
+		t.Fatal("expected a change")
+	}
+	if !strings.Contains(out, `url = "postgres://override"`) {
+		t.Fatalf("url was not overridden: %q", out)
+	}
+	if strings.Contains(out, `env("DATABASE_URL")`) {
+		t.Fatalf("old url expression was left behind: %q", out)
+	}
+
+	t.Run("no-op for unknown datasource name", func(t *testing.T) {
+		out, changed := OverrideDatasourceURL("other", "postgres://override")(src)
+		if changed || out != src {
+			t.Fatalf("expected no change for an unknown datasource name, got %q", out)
+		}
+	})
+
+	t.Run("ignores a commented-out url line above the real one", func(t *testing.T) {
+		src := "datasource db {\n  // url = env(\"OLD_URL\")\n  url = env(\"DATABASE_URL\")\n}\n"
+
+		out, changed := OverrideDatasourceURL("db", "postgres://override")(src)
+		if !changed {
+			t.Fatal("expected a change")
+		}
+		if !strings.Contains(out, `url = "postgres://override"`) {
+			t.Fatalf("url was not overridden: %q", out)
+		}
+		if !strings.Contains(out, `// url = env("OLD_URL")`) {
+			t.Fatalf("the commented-out line should have been left alone: %q", out)
+		}
+		if strings.Contains(out, `env("DATABASE_URL")`) {
+			t.Fatalf("old url expression was left behind: %q", out)
+		}
+	})
+}
+
+func TestResolvePath(t *testing.T) {
+	t.Run("passes through a plain file", func(t *testing.T) {
+		resolved, err := ResolvePath("schema.prisma")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resolved != "schema.prisma" {
+			t.Fatalf("expected path to pass through unchanged, got %q", resolved)
+		}
+	})
+
+	t.Run("finds schema.prisma in a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "schema.prisma"), "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n")
+		writeFile(t, filepath.Join(dir, "models.prisma"), "model User {\n  id Int @id\n}\n")
+
+		resolved, err := ResolvePath(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resolved != filepath.Join(dir, "schema.prisma") {
+			t.Fatalf("expected schema.prisma to be picked, got %q", resolved)
+		}
+	})
+
+	t.Run("falls back to the *.prisma file with a datasource block", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "models.prisma"), "model User {\n  id Int @id\n}\n")
+		writeFile(t, filepath.Join(dir, "main.prisma"), "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n")
+
+		resolved, err := ResolvePath(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resolved != filepath.Join(dir, "main.prisma") {
+			t.Fatalf("expected main.prisma to be picked, got %q", resolved)
+		}
+	})
+
+	t.Run("errors when nothing in the directory declares a datasource", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "models.prisma"), "model User {\n  id Int @id\n}\n")
+
+		if _, err := ResolvePath(dir); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestPatch(t *testing.T) {
+	t.Run("returns the original path unchanged when nothing changed", func(t *testing.T) {
+		dir := t.TempDir()
+		schemaFile := filepath.Join(dir, "schema.prisma")
+		writeFile(t, schemaFile, "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n")
+
+		resultPath, cleanup, err := Patch(schemaFile, EnsureDatasourceURL("DB_URL"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cleanup != nil {
+			t.Fatal("expected no cleanup since nothing changed")
+		}
+		if resultPath != schemaFile {
+			t.Fatalf("expected %q, got %q", schemaFile, resultPath)
+		}
+	})
+
+	t.Run("preserves a directory argument when nothing changed", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "schema.prisma"), "datasource db {\n  url = env(\"DATABASE_URL\")\n}\n")
+		writeFile(t, filepath.Join(dir, "models.prisma"), "model User {\n  id Int @id\n}\n")
+
+		resultPath, cleanup, err := Patch(dir, EnsureDatasourceURL("DB_URL"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cleanup != nil {
+			t.Fatal("expected no cleanup since nothing changed")
+		}
+		// The whole directory must be handed back, not the single
+		// schema.prisma file ResolvePath used internally to check for a
+		// url, or the sibling models.prisma would silently drop out of
+		// the multi-file schema.
+		if resultPath != dir {
+			t.Fatalf("expected directory %q to be preserved, got %q", dir, resultPath)
+		}
+	})
+
+	t.Run("writes a temp file when a patch changes something", func(t *testing.T) {
+		dir := t.TempDir()
+		schemaFile := filepath.Join(dir, "schema.prisma")
+		writeFile(t, schemaFile, "datasource db {\n  provider = \"postgresql\"\n}\n")
+
+		resultPath, cleanup, err := Patch(schemaFile, EnsureDatasourceURL("DB_URL"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cleanup == nil {
+			t.Fatal("expected cleanup for a patched temp file")
+		}
+		defer cleanup()
+
+		if resultPath == schemaFile {
+			t.Fatal("expected a temp file path distinct from the original")
+		}
+
+		content, err := os.ReadFile(resultPath)
+		if err != nil {
+			t.Fatalf("could not read patched file: %v", err)
+		}
+		if !strings.Contains(string(content), `url = env("DB_URL")`) {
+			t.Fatalf("patched content missing injected url: %q", content)
+		}
+	})
+
+	t.Run("keeps every sibling file when a directory's schema.prisma is patched", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "schema.prisma"), "datasource db {\n  provider = \"postgresql\"\n}\n")
+		writeFile(t, filepath.Join(dir, "models.prisma"), "model User {\n  id Int @id\n}\n")
+
+		resultPath, cleanup, err := Patch(dir, EnsureDatasourceURL("DB_URL"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cleanup == nil {
+			t.Fatal("expected cleanup for a patched temp directory")
+		}
+		defer cleanup()
+
+		if resultPath == dir {
+			t.Fatal("expected a temp directory path distinct from the original")
+		}
+		info, err := os.Stat(resultPath)
+		if err != nil || !info.IsDir() {
+			t.Fatalf("expected resultPath to be a directory, got %q (err=%v)", resultPath, err)
+		}
+
+		patched, err := os.ReadFile(filepath.Join(resultPath, "schema.prisma"))
+		if err != nil {
+			t.Fatalf("could not read patched schema.prisma: %v", err)
+		}
+		if !strings.Contains(string(patched), `url = env("DB_URL")`) {
+			t.Fatalf("patched content missing injected url: %q", patched)
+		}
+
+		models, err := os.ReadFile(filepath.Join(resultPath, "models.prisma"))
+		if err != nil {
+			t.Fatalf("expected models.prisma to be copied into the temp directory: %v", err)
+		}
+		if !strings.Contains(string(models), "model User") {
+			t.Fatalf("models.prisma content was not preserved: %q", models)
+		}
+	})
+}